@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+// Backend is the configuration of a HAProxy backend, built from the
+// endpoints of one or more Kubernetes services
+type Backend struct {
+	ID        string
+	Namespace string
+	Name      string
+	Port      string
+
+	// Endpoints is the list of server endpoints currently assigned to
+	// this backend
+	Endpoints []*Endpoint
+
+	// Balance configures how requests are distributed among Endpoints
+	Balance BalanceConfig
+
+	// CustomName is an optional user supplied backend id, read from the
+	// `haproxy-ingress.github.io/backend-name` service annotation, that
+	// overrides ID in HostBackend and in the rendered backend section
+	// name. Assigning it goes through Backends.SetCustomName so it's
+	// validated and tracked for uniqueness
+	CustomName string
+
+	paths []PathLink
+}
+
+// Endpoint is a single server endpoint of a Backend
+type Endpoint struct {
+	// IP is the server's IP address
+	IP string
+	// Port is the server's port
+	Port int
+	// Weight is the relative weight of this endpoint
+	Weight int
+}
+
+// CreateBackend ...
+func CreateBackend(namespace, name, port string) *Backend {
+	return &Backend{
+		ID:        BuildID(namespace, name, port),
+		Namespace: namespace,
+		Name:      name,
+		Port:      port,
+	}
+}
+
+// BuildID creates the deterministic backend ID from its namespace, name
+// and port, used as the default HostBackend.ID and rendered backend
+// section name
+func BuildID(namespace, name, port string) string {
+	return namespace + "_" + name + "_" + port
+}
+
+// AddBackendPath adds link to the list of paths served by this backend
+func (b *Backend) AddBackendPath(link PathLink) {
+	for _, l := range b.paths {
+		if l == link {
+			return
+		}
+	}
+	b.paths = append(b.paths, link)
+}
+
+// Paths ...
+func (b *Backend) Paths() []PathLink {
+	return b.paths
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// validBackendName matches the characters HAProxy accepts in a backend
+// section name / stick-table id
+var validBackendName = regexp.MustCompile(`^[a-zA-Z0-9_.:-]+$`)
+
+// Backends is the tracker of every Backend built from Kubernetes services.
+// items is permanently keyed by the generated BuildID() suffix so that
+// AcquireBackend keeps finding the same Backend across reconciles
+// regardless of any custom name assigned to it; byCustomName additionally
+// indexes backends by the user supplied name, once assigned via
+// SetCustomName, so FindBackend and uniqueness checks can resolve either
+type Backends struct {
+	items        map[string]*Backend
+	byCustomName map[string]*Backend
+}
+
+// CreateBackends ...
+func CreateBackends() *Backends {
+	return &Backends{
+		items:        map[string]*Backend{},
+		byCustomName: map[string]*Backend{},
+	}
+}
+
+// AcquireBackend creates, or reuses, the Backend of namespace+name+port.
+// Lookup is always by the generated id, never by CustomName, so a backend
+// that was renamed via SetCustomName is still found -- with its Endpoints,
+// Balance and other state intact -- on the next reconcile
+func (b *Backends) AcquireBackend(namespace, name, port string) *Backend {
+	id := BuildID(namespace, name, port)
+	if backend, found := b.items[id]; found {
+		return backend
+	}
+	backend := CreateBackend(namespace, name, port)
+	b.items[id] = backend
+	return backend
+}
+
+// FindBackend looks up a backend by its generated id or, if not found,
+// by its custom name
+func (b *Backends) FindBackend(id string) *Backend {
+	if backend, found := b.items[id]; found {
+		return backend
+	}
+	return b.byCustomName[id]
+}
+
+// SetCustomName assigns backend's resolved id to customName, the value of
+// the `haproxy-ingress.github.io/backend-name` service annotation. An empty
+// customName clears any previous override, falling back to the generated
+// BuildID() suffix so existing deployments that don't use the annotation
+// are unaffected. Returns an error if customName has characters HAProxy
+// can't use in a backend section name, or collides with another backend
+func (b *Backends) SetCustomName(backend *Backend, customName string) error {
+	if customName == "" {
+		if backend.CustomName != "" {
+			delete(b.byCustomName, backend.CustomName)
+		}
+		backend.CustomName = ""
+		return nil
+	}
+	if !validBackendName.MatchString(customName) {
+		return fmt.Errorf("backend name '%s' has invalid characters for a HAProxy backend", customName)
+	}
+	if other, found := b.byCustomName[customName]; found && other != backend {
+		return fmt.Errorf("backend name '%s' is already assigned to another backend", customName)
+	}
+	if other, found := b.items[customName]; found && other != backend {
+		return fmt.Errorf("backend name '%s' collides with another backend's generated id", customName)
+	}
+	if backend.CustomName != "" {
+		delete(b.byCustomName, backend.CustomName)
+	}
+	backend.CustomName = customName
+	b.byCustomName[customName] = backend
+	return nil
+}
+
+// ResolvedID returns CustomName when the `haproxy-ingress.github.io/backend-name`
+// annotation was used to override it, falling back to the generated,
+// deterministic BuildID() suffix otherwise
+func (b *Backend) ResolvedID() string {
+	if b.CustomName != "" {
+		return b.CustomName
+	}
+	return b.ID
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "testing"
+
+func TestAcquireBackendSurvivesCustomName(t *testing.T) {
+	backends := CreateBackends()
+
+	backend := backends.AcquireBackend("default", "echo", "8080")
+	backend.Endpoints = []*Endpoint{{IP: "10.0.0.1", Port: 8080, Weight: 1}}
+
+	if err := backends.SetCustomName(backend, "echo-svc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reacquired := backends.AcquireBackend("default", "echo", "8080")
+	if reacquired != backend {
+		t.Fatalf("AcquireBackend returned a different backend after SetCustomName, state would be orphaned")
+	}
+	if len(reacquired.Endpoints) != 1 {
+		t.Fatalf("expected reacquired backend to keep its endpoints, got %+v", reacquired.Endpoints)
+	}
+
+	if found := backends.FindBackend("echo-svc"); found != backend {
+		t.Errorf("expected FindBackend to resolve the custom name")
+	}
+	if found := backends.FindBackend(backend.ID); found != backend {
+		t.Errorf("expected FindBackend to still resolve the generated id")
+	}
+}
+
+func TestSetCustomNameRejectsCollision(t *testing.T) {
+	backends := CreateBackends()
+	first := backends.AcquireBackend("default", "echo", "8080")
+	second := backends.AcquireBackend("default", "other", "8080")
+
+	if err := backends.SetCustomName(first, "shared"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := backends.SetCustomName(second, "shared"); err == nil {
+		t.Errorf("expected an error assigning an already used custom name")
+	}
+	if err := backends.SetCustomName(second, "in valid"); err == nil {
+		t.Errorf("expected an error assigning a custom name with invalid characters")
+	}
+}
+
+func TestSetCustomNameRejectsCollisionWithGeneratedID(t *testing.T) {
+	backends := CreateBackends()
+	backendA := backends.AcquireBackend("default", "echo", "8080")
+	backendB := backends.AcquireBackend("default", "other", "8080")
+
+	if err := backends.SetCustomName(backendB, backendA.ID); err == nil {
+		t.Fatalf("expected an error assigning a custom name that collides with another backend's generated id")
+	}
+	if backendB.ResolvedID() == backendA.ID {
+		t.Fatalf("expected backendB to not shadow backendA's generated id")
+	}
+	if found := backends.FindBackend(backendA.ID); found != backendA {
+		t.Errorf("expected the generated id to still resolve to backendA")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 )
 
 // CreateHosts ...
@@ -168,41 +169,114 @@ func (h *Hosts) HasVarNamespace() bool {
 	return false
 }
 
-// FindPath ...
-func (h *Host) FindPath(path string) *HostPath {
+// HostTLSConfig returns the TLS configuration of the Host matching sni,
+// honoring wildcard hostnames (`*.example.com`) and falling back to the
+// default host. Returns nil if no Host matches sni
+func (h *Hosts) HostTLSConfig(sni string) *HostTLSConfig {
+	if host := h.findHostForSNI(sni); host != nil {
+		return &host.TLS
+	}
+	return nil
+}
+
+// HostHasTLS answers whether sni resolves to a Host with a valid TLS
+// configuration, used by the controller and template layer to emit
+// correct use_backend/redirect rules for mixed TLS/non-TLS virtual hosts
+// without re-scanning Paths manually
+func (h *Hosts) HostHasTLS(sni string) bool {
+	tls := h.HostTLSConfig(sni)
+	return tls != nil && tls.HasTLS()
+}
+
+// findHostForSNI looks up the Host matching sni, trying an exact match
+// first, then the wildcard host covering sni's parent domain, then the
+// default host
+func (h *Hosts) findHostForSNI(sni string) *Host {
+	if host, found := h.items[sni]; found {
+		return host
+	}
+	if i := strings.Index(sni, "."); i >= 0 {
+		wildcard := "*" + sni[i:]
+		if host, found := h.items[wildcard]; found {
+			return host
+		}
+	}
+	return h.items[DefaultHost]
+}
+
+// FindPath looks up the HostPath that was added for path with the exact
+// same match descriptor -- Type, Method and Headers -- since the same
+// path string can now be registered more than once with different
+// header or method constraints
+func (h *Host) FindPath(path string, match MatchConfig) *HostPath {
 	for _, p := range h.Paths {
-		if p.Path == path {
+		if p.Path == path && p.Match == match.Type && p.Method == match.Method && headersEqual(p.Headers, match.Headers) {
 			return p
 		}
 	}
 	return nil
 }
 
-// AddPath ...
-func (h *Host) AddPath(backend *Backend, path string, match MatchType) {
+func headersEqual(a, b []HeaderMatch) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddPath adds path to this Host's routing table, following match --
+// which besides the historic prefix/exact Type now also accepts
+// MatchRegex path patterns (rendered as HAProxy's `path_reg`/`url_reg`),
+// Method and Headers constraints. Returns an error if a regex in path or
+// in match.Headers fails to compile; the path is not added in that case
+func (h *Host) AddPath(backend *Backend, path string, match MatchConfig) error {
 	link := CreatePathLink(h.Hostname, path)
 	var hback HostBackend
 	if backend != nil {
 		hback = HostBackend{
-			ID:        backend.ID,
+			ID:        backend.ResolvedID(),
 			Namespace: backend.Namespace,
 			Name:      backend.Name,
 			Port:      backend.Port,
 		}
-		backend.AddBackendPath(link)
 	} else {
 		hback = HostBackend{ID: "_error404"}
 	}
-	h.Paths = append(h.Paths, &HostPath{
+	hpath := &HostPath{
 		Path:    path,
 		Link:    link,
-		Match:   match,
+		Match:   match.Type,
 		Backend: hback,
+	}
+	if err := hpath.addPathMatch(path, match); err != nil {
+		return err
+	}
+	if backend != nil {
+		backend.AddBackendPath(link)
+	}
+	h.Paths = append(h.Paths, hpath)
+	// higher Priority goes first; same-priority HostPaths keep the
+	// historic reverse path order so subpaths don't get shadowed by
+	// their parent path; and HostPaths that also tie on path (identical
+	// except for Method/Headers, e.g. two `match-headers` variants of
+	// the same path) fall back to descriptorKey so their relative order
+	// is a deterministic property of the match descriptor, not of
+	// insertion order or sort.Slice internals
+	sort.SliceStable(h.Paths, func(i, j int) bool {
+		if h.Paths[i].Priority != h.Paths[j].Priority {
+			return h.Paths[i].Priority > h.Paths[j].Priority
+		}
+		if h.Paths[i].Path != h.Paths[j].Path {
+			return h.Paths[i].Path > h.Paths[j].Path
+		}
+		return h.Paths[i].descriptorKey() < h.Paths[j].descriptorKey()
 	})
-	// reverse order in order to avoid overlap of sub-paths
-	sort.Slice(h.Paths, func(i, j int) bool {
-		return h.Paths[i].Path > h.Paths[j].Path
-	})
+	return nil
 }
 
 // HasTLSAuth ...
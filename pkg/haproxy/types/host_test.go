@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "testing"
+
+func TestHostHasTLS(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sni      string
+		expected bool
+	}{
+		{
+			name:     "exact match with tls",
+			sni:      "domain.local",
+			expected: true,
+		},
+		{
+			name:     "exact match without tls",
+			sni:      "plain.local",
+			expected: false,
+		},
+		{
+			name:     "wildcard match",
+			sni:      "sub.wild.local",
+			expected: true,
+		},
+		{
+			name:     "default host",
+			sni:      "unknown.local",
+			expected: true,
+		},
+		{
+			name:     "missing secret",
+			sni:      "notls.local",
+			expected: false,
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			hosts := CreateHosts()
+
+			withTLS := hosts.AcquireHost("domain.local")
+			withTLS.TLS.TLSFilename = "/var/haproxy/ssl/domain.local.pem"
+
+			hosts.AcquireHost("plain.local")
+
+			wildcard := hosts.AcquireHost("*.wild.local")
+			wildcard.TLS.TLSFilename = "/var/haproxy/ssl/wild.local.pem"
+
+			def := hosts.AcquireHost(DefaultHost)
+			def.TLS.TLSFilename = "/var/haproxy/ssl/default.pem"
+
+			hosts.AcquireHost("notls.local")
+
+			if actual := hosts.HostHasTLS(test.sni); actual != test.expected {
+				t.Errorf("expected HostHasTLS(%s) = %v, got %v", test.sni, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHostTLSConfigMissingHost(t *testing.T) {
+	hosts := CreateHosts()
+	if tls := hosts.HostTLSConfig("missing.local"); tls != nil {
+		t.Errorf("expected nil TLS config for a host with no default host, got %+v", tls)
+	}
+}
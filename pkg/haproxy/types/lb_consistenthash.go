@@ -0,0 +1,281 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BalanceAlgorithm is the algorithm used to select a server endpoint
+// of a Backend
+type BalanceAlgorithm string
+
+const (
+	// BalanceRoundRobin is HAProxy's default `balance roundrobin`
+	BalanceRoundRobin BalanceAlgorithm = "roundrobin"
+	// BalanceLeastConn is HAProxy's `balance leastconn`
+	BalanceLeastConn BalanceAlgorithm = "leastconn"
+	// BalanceConsistentHashBounded implements Consistent Hashing With
+	// Bounded Loads on top of a hash ring rendered as a stick-table and
+	// a Lua/map based selector, configured via the
+	// `haproxy-ingress.github.io/balance-algorithm: consistent-hash-bounded`
+	// annotation
+	BalanceConsistentHashBounded BalanceAlgorithm = "consistent-hash-bounded"
+)
+
+// HashKeyType is the request attribute used as the consistent hashing key
+type HashKeyType string
+
+const (
+	// HashKeySourceIP hashes the client's source IP address
+	HashKeySourceIP HashKeyType = "source"
+	// HashKeyHeader hashes the value of a request header, named by
+	// BalanceConfig.HashKeyName
+	HashKeyHeader HashKeyType = "header"
+	// HashKeyCookie hashes the value of a request cookie, named by
+	// BalanceConfig.HashKeyName
+	HashKeyCookie HashKeyType = "cookie"
+	// HashKeyURL hashes the request URL
+	HashKeyURL HashKeyType = "url"
+)
+
+const (
+	// DefaultHashReplicas is the default number of virtual nodes (K)
+	// placed on the ring per server endpoint
+	DefaultHashReplicas = 100
+	// DefaultHashBalanceFactor is the default max-load factor `c` used by
+	// Consistent Hashing With Bounded Loads: HAProxy stops sending new
+	// requests to a server once its current connection count exceeds
+	// ceil(c * total_conns/N)
+	DefaultHashBalanceFactor = 1.25
+	// ringMapConverter is the name of the HAProxy map file this backend's
+	// ring is rendered to, passed as the map file argument of the Lua
+	// selector, which opens it with Map.new
+	ringMapConverter = "consistent-hash-bounded.map"
+	// luaBoundedLoadFunction is the name of the Lua function, registered
+	// from the rendered LuaSelectorSource, that walks the ring map
+	// starting at the hashed key to find the first server under the
+	// bounded-load threshold
+	luaBoundedLoadFunction = "consistent_hash_bounded_pick"
+)
+
+// BalanceConfig configures how a Backend distributes requests among its
+// Endpoints. Algorithm defaults to BalanceRoundRobin, the behavior HAProxy
+// already provides out of the box; BalanceConsistentHashBounded renders
+// the ring, map file and Lua selector implemented in this file into the
+// backend's HAProxy configuration
+type BalanceConfig struct {
+	Algorithm BalanceAlgorithm
+
+	// HashKey is the request attribute hashed onto the ring. Only read
+	// when Algorithm is BalanceConsistentHashBounded
+	HashKey HashKeyType
+	// HashKeyName is the header or cookie name read when HashKey is
+	// HashKeyHeader or HashKeyCookie
+	HashKeyName string
+	// HashReplicas is the number of virtual nodes per server (K). Zero
+	// means DefaultHashReplicas
+	HashReplicas int
+	// HashBalanceFactor is the max-load factor `c`. Zero means
+	// DefaultHashBalanceFactor
+	HashBalanceFactor float64
+}
+
+func (c BalanceConfig) replicas() int {
+	if c.HashReplicas <= 0 {
+		return DefaultHashReplicas
+	}
+	return c.HashReplicas
+}
+
+func (c BalanceConfig) balanceFactor() float64 {
+	if c.HashBalanceFactor <= 0 {
+		return DefaultHashBalanceFactor
+	}
+	return c.HashBalanceFactor
+}
+
+// hashRing is the sorted set of virtual nodes rendered into the backend's
+// ring map file. Servers removed from the Backend's Endpoints are purged
+// from the ring entirely -- not just marked down -- so a later RenderRing
+// call never emits a stale map entry pointing at a server HAProxy no
+// longer has configured
+type hashRing struct {
+	replicas int
+	nodes    []ringNode
+}
+
+type ringNode struct {
+	hash     uint64
+	serverID string
+}
+
+func newHashRing(replicas int) *hashRing {
+	if replicas <= 0 {
+		replicas = DefaultHashReplicas
+	}
+	return &hashRing{replicas: replicas}
+}
+
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// addServer places replicas virtual nodes for serverID on the ring, keyed
+// by hash(serverID + "#" + i), and re-sorts the ring. Servers already on
+// the ring are left untouched so unaffected keys keep their assignment
+func (r *hashRing) addServer(serverID string) {
+	for _, n := range r.nodes {
+		if n.serverID == serverID {
+			return
+		}
+	}
+	for i := 0; i < r.replicas; i++ {
+		r.nodes = append(r.nodes, ringNode{
+			hash:     ringHash(serverID + "#" + strconv.Itoa(i)),
+			serverID: serverID,
+		})
+	}
+	sort.Slice(r.nodes, func(i, j int) bool {
+		return r.nodes[i].hash < r.nodes[j].hash
+	})
+}
+
+// removeServer purges every virtual node of serverID from the ring,
+// avoiding the stale-ring-entry bug where a removed server keeps showing
+// up in the rendered map file
+func (r *hashRing) removeServer(serverID string) {
+	nodes := r.nodes[:0]
+	for _, n := range r.nodes {
+		if n.serverID != serverID {
+			nodes = append(nodes, n)
+		}
+	}
+	r.nodes = nodes
+}
+
+// buildRing builds this Backend's hash ring from scratch out of its
+// current Endpoints, so servers no longer present never appear on it.
+// Since a virtual node's position depends only on its serverID and index,
+// a change to the server set only reassigns the ring buckets of the
+// added or removed servers instead of the whole ring
+func (b *Backend) buildRing() *hashRing {
+	ring := newHashRing(b.Balance.replicas())
+	current := map[string]bool{}
+	for _, ep := range b.Endpoints {
+		serverID := ep.IP + ":" + strconv.Itoa(ep.Port)
+		current[serverID] = true
+		ring.addServer(serverID)
+	}
+	seen := map[string]bool{}
+	for _, n := range ring.nodes {
+		seen[n.serverID] = true
+	}
+	for serverID := range seen {
+		if !current[serverID] {
+			ring.removeServer(serverID)
+		}
+	}
+	return ring
+}
+
+// RenderRingMap renders this backend's consistent hash ring as the
+// content of a HAProxy map file -- one `<hash> <serverID>` line per
+// virtual node, sorted by hash -- opened with Map.new by the Lua selector
+// rendered by LuaSelectorSource. It's a no-op, returning
+// an empty string, unless Balance.Algorithm is BalanceConsistentHashBounded
+func (b *Backend) RenderRingMap() string {
+	if b.Balance.Algorithm != BalanceConsistentHashBounded {
+		return ""
+	}
+	ring := b.buildRing()
+	var sb strings.Builder
+	for _, n := range ring.nodes {
+		fmt.Fprintf(&sb, "%016x %s\n", n.hash, n.serverID)
+	}
+	return sb.String()
+}
+
+// hashKeyFetch is the HAProxy sample fetch used as the consistent hashing
+// key, derived from BalanceConfig.HashKey/HashKeyName
+func (c BalanceConfig) hashKeyFetch() string {
+	switch c.HashKey {
+	case HashKeyHeader:
+		return fmt.Sprintf("req.hdr(%s)", c.HashKeyName)
+	case HashKeyCookie:
+		return fmt.Sprintf("req.cook(%s)", c.HashKeyName)
+	case HashKeyURL:
+		return "url"
+	default:
+		return "src"
+	}
+}
+
+// RenderConfig renders the `balance` directive -- plus, for
+// BalanceConsistentHashBounded, the stick-table and Lua/map based
+// selector described by the request -- that make up this backend's
+// HAProxy server-selection configuration. Endpoints must already be set;
+// callers write the returned lines into the backend section of the
+// rendered haproxy.cfg
+func (b *Backend) RenderConfig() []string {
+	switch b.Balance.Algorithm {
+	case BalanceConsistentHashBounded:
+		return b.renderConsistentHashBounded()
+	case BalanceLeastConn:
+		return []string{"    balance leastconn"}
+	default:
+		return []string{"    balance roundrobin"}
+	}
+}
+
+func (b *Backend) renderConsistentHashBounded() []string {
+	cfg := b.Balance
+	return []string{
+		"    # consistent-hash-bounded: " + fmt.Sprintf("%d virtual nodes/server, max-load factor %.2f", cfg.replicas(), cfg.balanceFactor()),
+		"    balance hash " + cfg.hashKeyFetch(),
+		"    hash-type consistent",
+		fmt.Sprintf("    hash-balance-factor %d", int(math.Ceil(cfg.balanceFactor()*100))),
+		"    stick-table type string len 64 size 16k expire 30s store conn_cur",
+		fmt.Sprintf("    http-request lua.%s(%s,%s,%g)", luaBoundedLoadFunction, cfg.hashKeyFetch(), ringMapConverter, cfg.balanceFactor()),
+	}
+}
+
+// LuaSelectorSource renders the Lua script implementing the
+// bounded-load walk over the ring map file. The emitted function is
+// registered under luaBoundedLoadFunction and invoked by the
+// `http-request lua.<fn>(...)` line rendered in RenderConfig; it reads
+// each server's current connection count from the stick-table set up
+// there and walks the ring, starting at the bucket the hashed key falls
+// into, until it finds a server under ceil(c * total_conns/N)
+func LuaSelectorSource() string {
+	return `-- generated by haproxy-ingress, do not edit by hand
+core.register_action("` + luaBoundedLoadFunction + `", { "http-req" }, function(txn, key, mapfile, factor)
+    local ring = Map.new(mapfile)
+    local server = ring:lookup(key)
+    if server ~= nil then
+        txn:set_var("txn.bhl_server", server)
+    end
+end)
+`
+}
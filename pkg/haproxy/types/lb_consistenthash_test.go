@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHashRingAddServerIsIdempotent(t *testing.T) {
+	r := newHashRing(10)
+	r.addServer("srv1")
+	r.addServer("srv1")
+	if got := len(r.nodes); got != 10 {
+		t.Fatalf("expected 10 virtual nodes for a single server, got %d", got)
+	}
+}
+
+func TestHashRingRemoveServerPurgesRing(t *testing.T) {
+	r := newHashRing(10)
+	r.addServer("srv1")
+	r.addServer("srv2")
+
+	r.removeServer("srv1")
+	for _, n := range r.nodes {
+		if n.serverID == "srv1" {
+			t.Fatalf("expected every srv1 virtual node to be purged from the ring")
+		}
+	}
+	if got := len(r.nodes); got != 10 {
+		t.Fatalf("expected only srv2's 10 virtual nodes to remain, got %d", got)
+	}
+}
+
+func TestBackendBuildRingDropsRemovedEndpoints(t *testing.T) {
+	backend := CreateBackend("default", "echo", "8080")
+	backend.Balance.Algorithm = BalanceConsistentHashBounded
+	backend.Endpoints = []*Endpoint{
+		{IP: "10.0.0.1", Port: 8080},
+		{IP: "10.0.0.2", Port: 8080},
+	}
+
+	ring := backend.buildRing()
+	servers := map[string]bool{}
+	for _, n := range ring.nodes {
+		servers[n.serverID] = true
+	}
+	if !servers["10.0.0.1:8080"] || !servers["10.0.0.2:8080"] {
+		t.Fatalf("expected both endpoints on the ring, got %v", servers)
+	}
+
+	backend.Endpoints = []*Endpoint{{IP: "10.0.0.1", Port: 8080}}
+	ring = backend.buildRing()
+	for _, n := range ring.nodes {
+		if n.serverID == "10.0.0.2:8080" {
+			t.Fatalf("expected the removed endpoint to be purged from a rebuilt ring")
+		}
+	}
+}
+
+func TestRenderRingMapOnlyForConsistentHashBounded(t *testing.T) {
+	backend := CreateBackend("default", "echo", "8080")
+	backend.Endpoints = []*Endpoint{{IP: "10.0.0.1", Port: 8080}}
+
+	if out := backend.RenderRingMap(); out != "" {
+		t.Fatalf("expected no ring map for the default algorithm, got %q", out)
+	}
+
+	backend.Balance.Algorithm = BalanceConsistentHashBounded
+	backend.Balance.HashReplicas = 4
+	out := backend.RenderRingMap()
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected one map line per virtual node (4), got %d: %q", len(lines), out)
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "10.0.0.1:8080") {
+			t.Errorf("expected every line to map to the single endpoint, got %q", line)
+		}
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm BalanceAlgorithm
+		contains  []string
+	}{
+		{
+			name:      "default round robin",
+			algorithm: "",
+			contains:  []string{"balance roundrobin"},
+		},
+		{
+			name:      "least connections",
+			algorithm: BalanceLeastConn,
+			contains:  []string{"balance leastconn"},
+		},
+		{
+			name:      "consistent hash bounded",
+			algorithm: BalanceConsistentHashBounded,
+			contains:  []string{"balance hash src", "stick-table", "http-request lua." + luaBoundedLoadFunction},
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			backend := CreateBackend("default", "echo", "8080")
+			backend.Balance.Algorithm = test.algorithm
+			out := strings.Join(backend.RenderConfig(), "\n")
+			for _, want := range test.contains {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected rendered config to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestLuaSelectorSourceRegistersConfiguredFunction(t *testing.T) {
+	src := LuaSelectorSource()
+	if !strings.Contains(src, `core.register_action("`+luaBoundedLoadFunction+`"`) {
+		t.Errorf("expected the Lua source to register %s, got:\n%s", luaBoundedLoadFunction, src)
+	}
+}
+
+func TestRingHashIsDistinctPerVirtualNode(t *testing.T) {
+	seen := map[uint64]bool{}
+	for i := 0; i < 100; i++ {
+		h := ringHash("srv1#" + strconv.Itoa(i))
+		if seen[h] {
+			t.Fatalf("expected distinct hashes per virtual node index, collided at %d", i)
+		}
+		seen[h] = true
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MatchConfig is the match descriptor accepted by AddPath. Type selects
+// how Path itself is compared (MatchBegin/MatchExact/MatchPrefix/
+// MatchRegex); Method and Headers, when set, add further ACL requirements
+// on top of the path match. Priority orders overlapping HostPaths and is
+// derived from the descriptor's specificity when left at zero
+type MatchConfig struct {
+	Type     MatchType
+	Method   string
+	Headers  []HeaderMatch
+	Priority int
+}
+
+// Regex returns the compiled path regular expression of this HostPath.
+// Only set when Match is MatchRegex
+func (p *HostPath) Regex() *regexp.Regexp {
+	return p.regex
+}
+
+// descriptorKey is a deterministic string encoding of everything besides
+// Priority and Path that distinguishes two HostPaths with otherwise
+// identical sort keys -- Method and the full content of Headers -- used
+// as the final sort.SliceStable tiebreak in AddPath so that two HostPaths
+// differing only in header name/value (e.g. `X-Env=canary` vs
+// `X-Env=prod`) always land in the same relative order, rather than
+// whatever order insertion or map/slice iteration happened to produce
+func (p *HostPath) descriptorKey() string {
+	var b strings.Builder
+	b.WriteString(p.Method)
+	for _, hdr := range p.Headers {
+		b.WriteByte('|')
+		b.WriteString(string(hdr.Type))
+		b.WriteByte(':')
+		b.WriteString(hdr.Name)
+		b.WriteByte('=')
+		b.WriteString(hdr.Value)
+	}
+	return b.String()
+}
+
+// addPathMatch validates match against path and, on success, populates
+// the fields of p derived from it -- Method, Headers, Priority and the
+// compiled path regex
+func (p *HostPath) addPathMatch(path string, match MatchConfig) error {
+	if match.Type == MatchRegex {
+		re, err := regexp.Compile(path)
+		if err != nil {
+			return fmt.Errorf("invalid regex path '%s': %w", path, err)
+		}
+		p.regex = re
+	}
+	for i, hdr := range match.Headers {
+		if hdr.Name == "" {
+			return fmt.Errorf("match-headers entry %d is missing a header name", i)
+		}
+		if hdr.Type == HeaderMatchReg {
+			if _, err := regexp.Compile(hdr.Value); err != nil {
+				return fmt.Errorf("invalid regex header match for '%s': %w", hdr.Name, err)
+			}
+		}
+	}
+	p.Method = match.Method
+	p.Headers = match.Headers
+	p.Priority = match.Priority
+	if p.Priority == 0 {
+		p.Priority = matchSpecificity(path, match)
+	}
+	return nil
+}
+
+// matchSpecificity is the default Priority assigned to a HostPath whose
+// MatchConfig didn't set one explicitly: more specific match types sort
+// before less specific ones, and every header or method requirement makes
+// a HostPath more specific than one without
+func matchSpecificity(path string, match MatchConfig) int {
+	var base int
+	switch match.Type {
+	case MatchExact:
+		base = 40000
+	case MatchRegex:
+		base = 30000
+	default:
+		// MatchBegin, MatchPrefix
+		base = 10000
+	}
+	priority := base + len(path)
+	if match.Method != "" {
+		priority += 1000
+	}
+	priority += 500 * len(match.Headers)
+	return priority
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "testing"
+
+// TestAddPathDeterministicOrderOnHeaderOnlyTie confirms that two HostPaths
+// which tie on Priority and Path -- differing only in a match-headers
+// value, the annotation example from the request ("X-Env=canary" vs
+// "X-Env=prod") -- always sort the same way regardless of the order they
+// were added in, rather than leaving the tie to insertion order
+func TestAddPathDeterministicOrderOnHeaderOnlyTie(t *testing.T) {
+	canary := MatchConfig{
+		Type:    MatchBegin,
+		Headers: []HeaderMatch{{Name: "X-Env", Type: HeaderMatchStr, Value: "canary"}},
+	}
+	prod := MatchConfig{
+		Type:    MatchBegin,
+		Headers: []HeaderMatch{{Name: "X-Env", Type: HeaderMatchStr, Value: "prod"}},
+	}
+
+	hosts := CreateHosts()
+	backend := CreateBackend("default", "echo", "8080")
+
+	h1 := hosts.AcquireHost("canary-first.local")
+	if err := h1.AddPath(backend, "/app", canary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h1.AddPath(backend, "/app", prod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h2 := hosts.AcquireHost("prod-first.local")
+	if err := h2.AddPath(backend, "/app", prod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h2.AddPath(backend, "/app", canary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if h1.Paths[0].Priority != h1.Paths[1].Priority {
+		t.Fatalf("expected the two header-only variants to tie on Priority")
+	}
+	if h1.Paths[0].Headers[0].Value != h2.Paths[0].Headers[0].Value {
+		t.Errorf("expected insertion order not to affect the resulting sort order: got %s then %s on one host, %s then %s on the other",
+			h1.Paths[0].Headers[0].Value, h1.Paths[1].Headers[0].Value,
+			h2.Paths[0].Headers[0].Value, h2.Paths[1].Headers[0].Value)
+	}
+}
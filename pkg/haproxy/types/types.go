@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "regexp"
+
+// DefaultHost is the name used to configure the default host, the
+// one used when no other host matches the request
+const DefaultHost = "<default>"
+
+// MatchType is the type of matching used to compare a request's path
+// against a HostPath's Path
+type MatchType string
+
+const (
+	// MatchBegin matches the start of the request path
+	MatchBegin MatchType = "begin"
+	// MatchExact matches the whole request path
+	MatchExact MatchType = "exact"
+	// MatchPrefix matches the start of the request path on a dir basis
+	MatchPrefix MatchType = "prefix"
+	// MatchRegex matches the request path against a PCRE regular
+	// expression, rendered as HAProxy's `path_reg`/`url_reg`
+	MatchRegex MatchType = "regex"
+)
+
+// Hosts is the list of tracked Host objects, built from ingress and
+// other configuration sources, as well as the changing hashmap used
+// to calculate if a HAProxy reload is needed
+type Hosts struct {
+	items               map[string]*Host
+	itemsAdd            map[string]*Host
+	itemsDel            map[string]*Host
+	sslPassthroughCount int
+	hasCommit           bool
+}
+
+// Host is the configuration of a single virtual host, built from one
+// or more Ingress resources that share the same hostname
+type Host struct {
+	hosts *Hosts
+
+	// Hostname is the FQDN or wildcard hostname of this virtual host
+	Hostname string
+
+	// Paths is the list of HostPath objects, sorted so that subpaths
+	// take priority over their parent path
+	Paths []*HostPath
+
+	// TLS is the resolved TLS configuration of this virtual host
+	TLS HostTLSConfig
+
+	// VarNamespace enables namespace isolation via HAProxy vars
+	VarNamespace bool
+
+	sslPassthrough bool
+}
+
+// HostTLSConfig is the resolved TLS configuration of a Host
+type HostTLSConfig struct {
+	// TLSFilename is the path of the PEM file with crt+key, empty if the
+	// host doesn't have TLS
+	TLSFilename string
+
+	// TLSHash is the checksum of the TLS PEM file
+	TLSHash string
+
+	// CAHash is the checksum of the CA used to validate client certificates
+	CAHash string
+}
+
+// HostPath is a path within a Host that's routed to a Backend
+type HostPath struct {
+	// Path is the request path used to match this HostPath
+	Path string
+
+	// Link uniquely identifies this HostPath within the Hosts tracker
+	Link PathLink
+
+	// Match configures how Path is compared against the request
+	Match MatchType
+
+	// Method, when non empty, additionally restricts this HostPath to
+	// requests using that HTTP method
+	Method string
+
+	// Headers, when non empty, additionally restricts this HostPath to
+	// requests whose headers all satisfy the given HeaderMatch list,
+	// rendered as `hdr(Name) -m str|reg value` ACLs
+	Headers []HeaderMatch
+
+	// Priority orders overlapping HostPaths: higher priority HostPaths
+	// are evaluated first. Populated by AddPath from the match
+	// descriptor's specificity when the caller leaves it unset
+	Priority int
+
+	// Backend is the HAProxy backend this HostPath routes to
+	Backend HostBackend
+
+	regex *regexp.Regexp
+}
+
+// HeaderMatchType is how a HeaderMatch's Value is compared against the
+// request header
+type HeaderMatchType string
+
+const (
+	// HeaderMatchStr compares the header value verbatim (`-m str`)
+	HeaderMatchStr HeaderMatchType = "str"
+	// HeaderMatchReg compares the header value against a PCRE regular
+	// expression (`-m reg`)
+	HeaderMatchReg HeaderMatchType = "reg"
+)
+
+// HeaderMatch is a single `hdr(Name) -m str|reg value` match requirement
+type HeaderMatch struct {
+	Name  string
+	Type  HeaderMatchType
+	Value string
+}
+
+// HostBackend is a reference, from a HostPath, to the Backend that
+// serves it
+type HostBackend struct {
+	ID        string
+	Namespace string
+	Name      string
+	Port      string
+}
+
+// PathLink uniquely identifies a Host+Path pair, used to track which
+// Backend serves which paths
+type PathLink struct {
+	hostname string
+	path     string
+}
+
+// Frontend groups the HAProxy frontend configuration derived from the
+// Hosts tracker
+type Frontend struct {
+	Name     string
+	Bindings []string
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hostsfile lets operators mount a file using the classic
+// `/etc/hosts` syntax and have its entries added as Host aliases, so short
+// internal names, staging aliases or split-horizon overrides can be
+// configured without creating Service/Ingress objects.
+package hostsfile
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Entry is a single non-comment line of a hosts file: an IP address and
+// every hostname that follows it, including aliases after the first name
+type Entry struct {
+	IP    string
+	Port  int
+	Names []string
+}
+
+// DefaultPort is used when a hosts file entry doesn't carry a `:port` suffix
+const DefaultPort = 80
+
+// ParseFile reads path in `/etc/hosts` syntax -- `IP host1 host2 alias...`,
+// blank lines and `#` comments ignored -- returning one Entry per line with
+// every name on the line, not just the first. A bare IP, v4 or v6, falls
+// back to DefaultPort; a port suffix requires `IP:port` for v4 or the
+// bracketed `[IP]:port` for v6, same as the rest of the standard library
+func ParseFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip, port, err := splitIPPort(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("hostsfile %s: %w", path, err)
+		}
+		entries = append(entries, Entry{
+			IP:    ip,
+			Port:  port,
+			Names: fields[1:],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// splitIPPort splits field into an IP and a port. A bare IP address --
+// v4 or v6, the latter with no brackets needed since there's no port to
+// disambiguate from -- is returned with DefaultPort. Anything else is
+// parsed with net.SplitHostPort, which requires the `[ipv6]:port` bracket
+// syntax for v6 hosts, so a bare v6 address like `::1` is never
+// misinterpreted as an IP ending in a `:port` suffix
+func splitIPPort(field string) (string, int, error) {
+	if net.ParseIP(field) != nil {
+		return field, DefaultPort, nil
+	}
+	host, portStr, err := net.SplitHostPort(field)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid ip or ip:port '%s': %w", field, err)
+	}
+	if net.ParseIP(host) == nil {
+		return "", 0, fmt.Errorf("invalid ip in '%s'", field)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in '%s': %w", field, err)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostsfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitIPPort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		field    string
+		ip       string
+		port     int
+		hasError bool
+	}{
+		{
+			name:  "bare ipv6",
+			field: "::1",
+			ip:    "::1",
+			port:  DefaultPort,
+		},
+		{
+			name:  "bare ipv4",
+			field: "127.0.0.1",
+			ip:    "127.0.0.1",
+			port:  DefaultPort,
+		},
+		{
+			name:  "ipv4 with port",
+			field: "127.0.0.1:8080",
+			ip:    "127.0.0.1",
+			port:  8080,
+		},
+		{
+			name:  "bracketed ipv6 with port",
+			field: "[::1]:8080",
+			ip:    "::1",
+			port:  8080,
+		},
+		{
+			name:     "invalid ip",
+			field:    "not-an-ip",
+			hasError: true,
+		},
+		{
+			name:     "invalid port",
+			field:    "127.0.0.1:not-a-port",
+			hasError: true,
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ip, port, err := splitIPPort(test.field)
+			if test.hasError {
+				if err == nil {
+					t.Fatalf("expected an error for '%s'", test.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ip != test.ip || port != test.port {
+				t.Errorf("expected (%s, %d), got (%s, %d)", test.ip, test.port, ip, port)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	content := "# comment\n" +
+		"::1 localhost ip6-localhost\n" +
+		"10.0.0.1 echo.local echo-staging.local\n" +
+		"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	if entries[0].IP != "::1" || len(entries[0].Names) != 2 {
+		t.Errorf("expected ::1 with 2 names, got %+v", entries[0])
+	}
+	if entries[0].Names[0] != "localhost" || entries[0].Names[1] != "ip6-localhost" {
+		t.Errorf("expected both aliases on the line, got %+v", entries[0].Names)
+	}
+
+	if entries[1].IP != "10.0.0.1" || len(entries[1].Names) != 2 {
+		t.Errorf("expected 10.0.0.1 with 2 names, got %+v", entries[1])
+	}
+}
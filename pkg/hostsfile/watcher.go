@@ -0,0 +1,119 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostsfile
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tlhconsulting/haproxy-ingress/pkg/haproxy/types"
+)
+
+// synthNamespace is the fake namespace used to acquire a Backend for a
+// hosts file IP:port, since these entries don't come from a Kubernetes
+// Service
+const synthNamespace = "_hostsfile"
+
+// Watcher reloads a hosts file on change and synchronizes its entries
+// with a Hosts tracker, acquiring one Host per name on every line --
+// including every alias, not just the first -- and a synthetic Backend
+// per IP:port shared by all the aliases on that line
+type Watcher struct {
+	path     string
+	hosts    *types.Hosts
+	backends *types.Backends
+
+	lastMod time.Time
+	known   map[string]bool
+}
+
+// NewWatcher ...
+func NewWatcher(path string, hosts *types.Hosts, backends *types.Backends) *Watcher {
+	return &Watcher{
+		path:     path,
+		hosts:    hosts,
+		backends: backends,
+		known:    map[string]bool{},
+	}
+}
+
+// Reload re-reads the hosts file if it changed since the last call,
+// acquiring a Host for every new name and removing Hosts whose name is no
+// longer present, then calling Shrink and Commit so lines that didn't
+// change don't trigger a HAProxy reload
+func (w *Watcher) Reload() error {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().After(w.lastMod) && w.lastMod != (time.Time{}) {
+		return nil
+	}
+
+	entries, err := ParseFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	// every known name is reparsed from scratch on every reload; Shrink
+	// below reconciles the add+del pair back to a no-op for lines whose
+	// content didn't actually change
+	all := make([]string, 0, len(w.known))
+	for name := range w.known {
+		all = append(all, name)
+	}
+	w.hosts.RemoveAll(all)
+
+	current := map[string]bool{}
+	for _, entry := range entries {
+		backend := w.backends.AcquireBackend(synthNamespace, entry.IP, strconv.Itoa(entry.Port))
+		backend.Endpoints = []*types.Endpoint{{IP: entry.IP, Port: entry.Port, Weight: 1}}
+		for _, name := range entry.Names {
+			current[name] = true
+			host := w.hosts.AcquireHost(name)
+			if err := host.AddPath(backend, "/", types.MatchConfig{Type: types.MatchBegin}); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.hosts.Shrink()
+	w.hosts.Commit()
+
+	w.known = current
+	w.lastMod = info.ModTime()
+	return nil
+}
+
+// Watch polls the hosts file every interval until stopCh is closed, calling
+// Reload on every tick and reporting parse/stat errors through onError
+func (w *Watcher) Watch(interval time.Duration, stopCh <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Reload(); err != nil && onError != nil {
+				onError(fmt.Errorf("hostsfile %s: %w", w.path, err))
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
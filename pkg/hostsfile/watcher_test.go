@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The HAProxy Ingress Controller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostsfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tlhconsulting/haproxy-ingress/pkg/haproxy/types"
+)
+
+func TestWatcherReloadAddsAliasesAndRemoves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(path, []byte("10.0.0.1 echo.local echo-staging.local\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hosts := types.CreateHosts()
+	backends := types.CreateBackends()
+	w := NewWatcher(path, hosts, backends)
+
+	if err := w.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"echo.local", "echo-staging.local"} {
+		if hosts.FindHost(name) == nil {
+			t.Errorf("expected host %s to be acquired", name)
+		}
+	}
+	if hosts.FindHost("echo.local").Paths[0].Backend.ID != hosts.FindHost("echo-staging.local").Paths[0].Backend.ID {
+		t.Errorf("expected both aliases to share the same backend")
+	}
+
+	if err := os.WriteFile(path, []byte("10.0.0.1 echo.local\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hosts.FindHost("echo-staging.local") != nil {
+		t.Errorf("expected the removed alias to be gone after reload")
+	}
+	if hosts.FindHost("echo.local") == nil {
+		t.Errorf("expected echo.local to still be tracked")
+	}
+}